@@ -0,0 +1,99 @@
+package raft
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// addressProtocolVersion identifies the shape of the payload nested inside
+// an Address's versioned RLP envelope (see EncodeRLP). Bump this whenever a
+// change to addressFieldsV0 can't be expressed as a backwards-compatible
+// trailing field.
+type addressProtocolVersion uint8
+
+const addressProtoV0 addressProtocolVersion = 0
+
+// raftAddressVersioningEnabled gates whether Address.EncodeRLP emits the
+// versioned [version, payload] envelope or keeps writing the legacy
+// unversioned 5-element list that every released version of this package
+// has always understood. It only gets flipped once every member of the raft
+// cluster is running code new enough to decode the envelope; flipping it
+// early would produce LevelDB entries and raft transport messages that
+// older peers choke on.
+var raftAddressVersioningEnabled uint32
+
+// EnableAddressProtocolVersioning switches newly encoded Address records to
+// the versioned envelope. Callers (RaftService, once it has confirmed the
+// whole cluster has been upgraded) must not call this until every peer can
+// decode it; DecodeRLP always accepts both formats, so it is safe to enable
+// mid-rollout for reads, but not for writes.
+func EnableAddressProtocolVersioning() {
+	atomic.StoreUint32(&raftAddressVersioningEnabled, 1)
+}
+
+func (addr *Address) EncodeRLP(w io.Writer) error {
+	fields := addr.toFieldsV0()
+	if atomic.LoadUint32(&raftAddressVersioningEnabled) == 0 {
+		return rlp.Encode(w, fields)
+	}
+	return rlp.Encode(w, []interface{}{addressProtoV0, fields})
+}
+
+// DecodeRLP accepts both the legacy unversioned 5-element list and the
+// versioned [version, payload] envelope. The two are distinguished without
+// a dedicated marker: in the versioned form the envelope's second element is
+// itself a list (the payload), while in the legacy form it is NodeId's raw
+// byte string. Peeking at that element's kind is enough to tell them apart,
+// since every real RaftId/version value only ever occupies the first slot.
+func (addr *Address) DecodeRLP(s *rlp.Stream) error {
+	if _, err := s.List(); err != nil {
+		return err
+	}
+
+	first, err := s.Uint64()
+	if err != nil {
+		return err
+	}
+
+	kind, _, err := s.Kind()
+	if err != nil {
+		return err
+	}
+
+	var fields addressFieldsV0
+	if kind == rlp.List {
+		switch version := addressProtocolVersion(first); version {
+		case addressProtoV0:
+			if err := s.Decode(&fields); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("raft: Address encoded with unsupported protocol version %d", version)
+		}
+	} else {
+		// Legacy format: no version prefix, exactly 5 elements already
+		// consumed one at a time starting with RaftId.
+		fields.RaftId = uint16(first)
+		if err := s.Decode(&fields.NodeId); err != nil {
+			return err
+		}
+		if err := s.Decode(&fields.Ip); err != nil {
+			return err
+		}
+		if err := s.Decode(&fields.P2pPort); err != nil {
+			return err
+		}
+		if err := s.Decode(&fields.RaftPort); err != nil {
+			return err
+		}
+	}
+
+	if err := s.ListEnd(); err != nil {
+		return err
+	}
+	addr.fromFieldsV0(&fields)
+	return nil
+}
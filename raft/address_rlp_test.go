@@ -0,0 +1,154 @@
+package raft
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+func sampleAddress() Address {
+	return Address{
+		RaftId:   3,
+		NodeId:   [64]byte{1, 2, 3, 4, 5},
+		Ip:       net.IPv4(10, 0, 0, 1),
+		P2pPort:  enr.TCP(30303),
+		RaftPort: enr.RAFTPORT(50000),
+	}
+}
+
+func withVersioningEnabled(t *testing.T, enabled bool) {
+	t.Helper()
+	var v uint32
+	if enabled {
+		v = 1
+	}
+	old := atomic.SwapUint32(&raftAddressVersioningEnabled, v)
+	t.Cleanup(func() { atomic.StoreUint32(&raftAddressVersioningEnabled, old) })
+}
+
+func TestAddressDecodeLegacyFormat(t *testing.T) {
+	withVersioningEnabled(t, false)
+
+	addr := sampleAddress()
+	enc, err := rlp.EncodeToBytes(&addr)
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	var out Address
+	if err := rlp.DecodeBytes(enc, &out); err != nil {
+		t.Fatalf("decode of legacy format failed: %v", err)
+	}
+	if out.RaftId != addr.RaftId || out.NodeId != addr.NodeId {
+		t.Fatalf("round trip mismatch: got %+v, want %+v", out, addr)
+	}
+}
+
+func TestAddressDecodeVersionedFormat(t *testing.T) {
+	withVersioningEnabled(t, true)
+
+	addr := sampleAddress()
+	enc, err := rlp.EncodeToBytes(&addr)
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	var out Address
+	if err := rlp.DecodeBytes(enc, &out); err != nil {
+		t.Fatalf("decode of versioned format failed: %v", err)
+	}
+	if out.RaftId != addr.RaftId || out.NodeId != addr.NodeId {
+		t.Fatalf("round trip mismatch: got %+v, want %+v", out, addr)
+	}
+}
+
+// TestAddressDecodeCrossVersion proves a node that has flipped
+// raftAddressVersioningEnabled can still decode records a not-yet-upgraded
+// peer wrote in the legacy format, and vice versa.
+func TestAddressDecodeCrossVersion(t *testing.T) {
+	addr := sampleAddress()
+
+	withVersioningEnabled(t, false)
+	legacyEnc, err := rlp.EncodeToBytes(&addr)
+	if err != nil {
+		t.Fatalf("legacy encode failed: %v", err)
+	}
+
+	withVersioningEnabled(t, true)
+	versionedEnc, err := rlp.EncodeToBytes(&addr)
+	if err != nil {
+		t.Fatalf("versioned encode failed: %v", err)
+	}
+
+	for name, enc := range map[string][]byte{"legacy": legacyEnc, "versioned": versionedEnc} {
+		var out Address
+		if err := rlp.DecodeBytes(enc, &out); err != nil {
+			t.Fatalf("%s: decode failed: %v", name, err)
+		}
+		if out.RaftId != addr.RaftId || out.NodeId != addr.NodeId || out.P2pPort != addr.P2pPort {
+			t.Fatalf("%s: round trip mismatch: got %+v, want %+v", name, out, addr)
+		}
+	}
+}
+
+// TestAddressDecodeExtendedPayload proves a versioned payload with trailing
+// elements this release doesn't understand (e.g. a hostname or capability
+// flags added by a newer node) decodes without error instead of failing the
+// whole record; the extra elements land in addressFieldsV0.Rest and are not
+// carried into the decoded Address.
+func TestAddressDecodeExtendedPayload(t *testing.T) {
+	addr := sampleAddress()
+	fields := addr.toFieldsV0()
+
+	extra, err := rlp.EncodeToBytes("future-field")
+	if err != nil {
+		t.Fatalf("failed to encode extra element: %v", err)
+	}
+	fields.Rest = []rlp.RawValue{extra}
+
+	enc, err := rlp.EncodeToBytes([]interface{}{addressProtoV0, fields})
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	var out Address
+	if err := rlp.DecodeBytes(enc, &out); err != nil {
+		t.Fatalf("decode of extended payload failed: %v", err)
+	}
+	if out.RaftId != addr.RaftId || out.NodeId != addr.NodeId {
+		t.Fatalf("round trip mismatch: got %+v, want %+v", out, addr)
+	}
+}
+
+// TestAddressDecodeTruncatedList proves a payload missing trailing fields
+// (e.g. truncated by a buggy intermediary) still fails cleanly rather than
+// panicking or succeeding with zero-valued fields silently swallowed as
+// "unknown trailing data".
+func TestAddressDecodeTruncatedList(t *testing.T) {
+	addr := sampleAddress()
+	enc, err := rlp.EncodeToBytes([]interface{}{addr.RaftId, addr.NodeId, addr.Ip})
+	if err != nil {
+		t.Fatalf("failed to build truncated legacy list: %v", err)
+	}
+
+	var out Address
+	if err := rlp.DecodeBytes(enc, &out); err == nil {
+		t.Fatal("expected decode of truncated legacy list to fail")
+	}
+}
+
+func TestAddressDecodeUnsupportedVersion(t *testing.T) {
+	addr := sampleAddress()
+	enc, err := rlp.EncodeToBytes([]interface{}{addressProtocolVersion(255), addr.toFieldsV0()})
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	var out Address
+	if err := rlp.DecodeBytes(enc, &out); err == nil {
+		t.Fatal("expected decode with unsupported protocol version to fail")
+	}
+}
@@ -1,18 +1,21 @@
 package raft
 
 import (
-	"io"
 	"net"
 
 	"fmt"
-	"log"
 
 	"crypto/ecdsa"
+	"github.com/ethereum/go-ethereum/common/hexutil"
+	"github.com/ethereum/go-ethereum/crypto"
 	"github.com/ethereum/go-ethereum/p2p/enode"
 	"github.com/ethereum/go-ethereum/p2p/enr"
 	"github.com/ethereum/go-ethereum/rlp"
 )
 
+//go:generate go run ../../rlp/rlpgen -type addressFieldsV0 -out gen_address_rlp.go
+//go:generate go run github.com/fjl/gencodec -type Address -field-override addressMarshaling -out gen_address_json.go
+
 const nodeIDBits = 512
 
 type EnodeID [nodeIDBits / 8]byte
@@ -20,12 +23,69 @@ type EnodeID [nodeIDBits / 8]byte
 // Serializable information about a Peer. Sufficient to build `etcdRaft.Peer`
 // or `discover.Node`.
 type Address struct {
-	RaftId   uint16       `json:"raftId"`
-	NodeId   [64]byte     `json:"nodeId"`
-	Ip       net.IP       `json:"ip"`
-	P2pPort  enr.TCP      `json:"p2pPort"`
-	RaftPort enr.RAFTPORT `json:"raftPort"`
-	PubKey   *ecdsa.PublicKey
+	RaftId   uint16           `json:"raftId"`
+	NodeId   [64]byte         `json:"nodeId"`
+	Ip       net.IP           `json:"ip"`
+	P2pPort  enr.TCP          `json:"p2pPort"`
+	RaftPort enr.RAFTPORT     `json:"raftPort"`
+	PubKey   *ecdsa.PublicKey `json:"pubKey,omitempty"`
+}
+
+// addressFieldsV0 is the version-0 payload carried inside an Address's RLP
+// envelope (see EncodeRLP/DecodeRLP in address_rlp.go). It mirrors Address's
+// own wire fields and is the rlpgen target, so adding an optional field here
+// only requires a version bump if older nodes must reject it outright;
+// trailing elements they don't recognize are preserved in Rest instead of
+// causing a decode error.
+type addressFieldsV0 struct {
+	RaftId   uint16
+	NodeId   [64]byte
+	Ip       net.IP
+	P2pPort  enr.TCP
+	RaftPort enr.RAFTPORT
+	Rest     []rlp.RawValue `rlp:"tail"`
+}
+
+func (addr *Address) toFieldsV0() *addressFieldsV0 {
+	return &addressFieldsV0{
+		RaftId:   addr.RaftId,
+		NodeId:   addr.NodeId,
+		Ip:       addr.Ip,
+		P2pPort:  addr.P2pPort,
+		RaftPort: addr.RaftPort,
+	}
+}
+
+func (addr *Address) fromFieldsV0(fields *addressFieldsV0) {
+	addr.RaftId = fields.RaftId
+	addr.NodeId = fields.NodeId
+	addr.Ip = fields.Ip
+	addr.P2pPort = fields.P2pPort
+	addr.RaftPort = fields.RaftPort
+}
+
+// derivePubKey recomputes addr.PubKey from addr.NodeId, which is the
+// authoritative wire representation; PubKey itself is never read back over
+// JSON (see addressMarshaling). Called from Address.UnmarshalJSON in
+// gen_address_json.go; this is business logic gencodec has no way to
+// generate, so it lives here rather than in the generated file.
+func (addr *Address) derivePubKey() error {
+	pub, err := crypto.UnmarshalPubkey(append([]byte{0x04}, addr.NodeId[:]...))
+	if err != nil {
+		return err
+	}
+	addr.PubKey = pub
+	return nil
+}
+
+// addressMarshaling is the field type substitution used by gencodec to
+// produce Address.MarshalJSON/UnmarshalJSON. PubKey is written in its
+// compressed form but not read back; it is re-derived from NodeId when an
+// Address is unmarshaled.
+type addressMarshaling struct {
+	NodeId hexutil.Bytes
+	Ip     hexutil.Bytes
+	PubKey hexutil.Bytes
 }
 
 func newAddress(raftId uint16, raftPort int, node *enode.Node) *Address {
@@ -46,45 +106,51 @@ type Peer struct {
 	p2pNode *enode.Node // For ethereum transport
 }
 
-func (addr *Address) EncodeRLP(w io.Writer) error {
-	return rlp.Encode(w, []interface{}{addr.RaftId, addr.NodeId, addr.Ip, addr.P2pPort, addr.RaftPort})
-}
-
-func (addr *Address) DecodeRLP(s *rlp.Stream) error {
-	// These fields need to be public:
-	var temp struct {
-		RaftId   uint16
-		NodeId   enode.ID
-		Ip       net.IP
-		P2pPort  enr.TCP
-		RaftPort enr.RAFTPORT
-	}
-
-	if err := s.Decode(&temp); err != nil {
-		return err
-	} else {
-		addr.RaftId, addr.NodeId, addr.Ip, addr.P2pPort, addr.RaftPort = temp.RaftId, temp.NodeId, temp.Ip, temp.P2pPort, temp.RaftPort
-		return nil
-	}
-}
-
 // RLP Address encoding, for transport over raft and storage in LevelDB.
+// EncodeRLP/DecodeRLP live in address_rlp.go: they carry addressFieldsV0
+// either as the bare legacy list or inside the versioned envelope, and the
+// codec of addressFieldsV0 itself is generated by rlpgen into
+// gen_address_rlp.go.
 
 func (addr *Address) toBytes() []byte {
-	size, r, err := rlp.EncodeToReader(addr)
+	bytes, err := rlp.EncodeToBytes(addr)
 	if err != nil {
 		panic(fmt.Sprintf("error: failed to RLP-encode Address: %s", err.Error()))
 	}
-	var buffer = make([]byte, uint32(size))
-	r.Read(buffer)
-
-	return buffer
+	return bytes
 }
 
-func bytesToAddress(bytes []byte) *Address {
+// bytesToAddress decodes an Address received over the raft transport or read
+// back from LevelDB. The input is network-controlled (it arrives on the
+// peer-add path), so a malformed or forged record is reported as an error
+// for the caller to reject/skip that peer, never treated as fatal: a forged
+// SignedAddress must not be able to take the node down.
+//
+// When signed peers mode is enabled, a record that parses as a
+// SignedAddress must also verify; one that fails verification is rejected
+// outright. A record that doesn't parse as a SignedAddress at all falls back
+// to the plain Address decode, so unsigned records already in LevelDB or
+// still being sent by not-yet-upgraded peers keep working during a
+// cluster-wide rollout to signed peers mode - unless strict mode is also
+// enabled, in which case that fallback is disabled and only a verified
+// SignedAddress is accepted, for use once the cluster has finished migrating
+// and unsigned records are no longer expected from anyone.
+func bytesToAddress(bytes []byte) (*Address, error) {
+	if signedPeersEnabled() {
+		var signed SignedAddress
+		if err := rlp.DecodeBytes(bytes, &signed); err == nil {
+			if err := signed.Verify(); err != nil {
+				return nil, fmt.Errorf("rejecting raft peer address: %v", err)
+			}
+			return &signed.Address, nil
+		} else if signedPeersStrict() {
+			return nil, fmt.Errorf("rejecting raft peer address: not a signed address record: %v", err)
+		}
+	}
+
 	var addr Address
 	if err := rlp.DecodeBytes(bytes, &addr); err != nil {
-		log.Fatalf("failed to RLP-decode Address: %v", err)
+		return nil, fmt.Errorf("failed to RLP-decode Address: %v", err)
 	}
-	return &addr
+	return &addr, nil
 }
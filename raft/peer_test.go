@@ -0,0 +1,114 @@
+package raft
+
+import (
+	"bytes"
+	"math/rand"
+	"net"
+	"reflect"
+	"testing"
+	"testing/quick"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// legacyEncodeRLP reproduces the hand-written encoding that Address used
+// before switching to the generated codec in gen_address_rlp.go. It is kept
+// here only to prove the new codec stays byte-compatible with data already
+// sitting in LevelDB.
+func legacyEncodeRLP(addr *Address) ([]byte, error) {
+	return rlp.EncodeToBytes([]interface{}{addr.RaftId, addr.NodeId, addr.Ip, addr.P2pPort, addr.RaftPort})
+}
+
+// Generate lets testing/quick produce random Addresses for the fuzz-parity
+// checks below.
+func (Address) Generate(rand *rand.Rand, size int) reflect.Value {
+	var nodeId [64]byte
+	rand.Read(nodeId[:])
+
+	ip := make(net.IP, 4)
+	rand.Read(ip)
+
+	addr := Address{
+		RaftId:   uint16(rand.Uint32()),
+		NodeId:   nodeId,
+		Ip:       ip,
+		P2pPort:  enr.TCP(uint16(rand.Uint32())),
+		RaftPort: enr.RAFTPORT(uint16(rand.Uint32())),
+	}
+	return reflect.ValueOf(addr)
+}
+
+func TestAddressRLPMatchesLegacyEncoding(t *testing.T) {
+	check := func(addr Address) bool {
+		legacy, err := legacyEncodeRLP(&addr)
+		if err != nil {
+			t.Fatalf("legacy encode failed: %v", err)
+		}
+		generated, err := rlp.EncodeToBytes(&addr)
+		if err != nil {
+			t.Fatalf("generated encode failed: %v", err)
+		}
+		return bytes.Equal(legacy, generated)
+	}
+	if err := quick.Check(check, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAddressRLPRoundTrip(t *testing.T) {
+	check := func(addr Address) bool {
+		enc, err := rlp.EncodeToBytes(&addr)
+		if err != nil {
+			t.Fatalf("encode failed: %v", err)
+		}
+		var out Address
+		if err := rlp.DecodeBytes(enc, &out); err != nil {
+			t.Fatalf("decode failed: %v", err)
+		}
+		return out.RaftId == addr.RaftId &&
+			out.NodeId == addr.NodeId &&
+			out.Ip.Equal(addr.Ip) &&
+			out.P2pPort == addr.P2pPort &&
+			out.RaftPort == addr.RaftPort
+	}
+	if err := quick.Check(check, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestAddressJSONRoundTrip(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+
+	var nodeId [64]byte
+	copy(nodeId[:], crypto.FromECDSAPub(&key.PublicKey)[1:])
+
+	addr := Address{
+		RaftId:   7,
+		NodeId:   nodeId,
+		Ip:       net.IPv4(127, 0, 0, 1),
+		P2pPort:  enr.TCP(30303),
+		RaftPort: enr.RAFTPORT(50000),
+		PubKey:   &key.PublicKey,
+	}
+	data, err := addr.MarshalJSON()
+	if err != nil {
+		t.Fatalf("marshal failed: %v", err)
+	}
+
+	var out Address
+	if err := out.UnmarshalJSON(data); err != nil {
+		t.Fatalf("unmarshal failed: %v", err)
+	}
+	if out.RaftId != addr.RaftId || out.NodeId != addr.NodeId || !out.Ip.Equal(addr.Ip) ||
+		out.P2pPort != addr.P2pPort || out.RaftPort != addr.RaftPort {
+		t.Fatalf("round trip mismatch: got %+v, want %+v", out, addr)
+	}
+	if out.PubKey == nil || out.PubKey.X.Cmp(addr.PubKey.X) != 0 || out.PubKey.Y.Cmp(addr.PubKey.Y) != 0 {
+		t.Fatal("expected PubKey to be re-derived from NodeId")
+	}
+}
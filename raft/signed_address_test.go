@@ -0,0 +1,214 @@
+package raft
+
+import (
+	"crypto/ecdsa"
+	"net"
+	"sync/atomic"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/crypto"
+	"github.com/ethereum/go-ethereum/p2p/enr"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// generateAddressKey returns a key and the 64-byte NodeId encoding that
+// Address expects for it, so tests don't repeat the conversion.
+func generateAddressKey(t *testing.T) (*ecdsa.PrivateKey, [64]byte) {
+	t.Helper()
+	priv, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	var nodeId [64]byte
+	copy(nodeId[:], crypto.FromECDSAPub(&priv.PublicKey)[1:])
+	return priv, nodeId
+}
+
+func TestSignedAddressSignAndVerify(t *testing.T) {
+	priv, nodeId := generateAddressKey(t)
+	addr := Address{
+		RaftId:   1,
+		NodeId:   nodeId,
+		Ip:       net.IPv4(10, 0, 0, 2),
+		P2pPort:  enr.TCP(30303),
+		RaftPort: enr.RAFTPORT(50000),
+		PubKey:   &priv.PublicKey,
+	}
+	signed := NewSignedAddress(addr, 1)
+
+	if err := signed.Verify(); err == nil {
+		t.Fatal("expected Verify to fail before Sign")
+	}
+	if err := signed.Sign(priv); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	if err := signed.Verify(); err != nil {
+		t.Fatalf("Verify failed after Sign: %v", err)
+	}
+}
+
+func TestSignedAddressVerifyRejectsForgedNodeId(t *testing.T) {
+	priv, nodeId := generateAddressKey(t)
+	addr := Address{
+		RaftId:   1,
+		NodeId:   nodeId,
+		Ip:       net.IPv4(10, 0, 0, 2),
+		P2pPort:  enr.TCP(30303),
+		RaftPort: enr.RAFTPORT(50000),
+	}
+	signed := NewSignedAddress(addr, 1)
+	if err := signed.Sign(priv); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	// Forge a different NodeId onto the already-signed record.
+	signed.Address.NodeId[0] ^= 0xff
+
+	if err := signed.Verify(); err == nil {
+		t.Fatal("expected Verify to reject a forged NodeId")
+	}
+}
+
+func TestSignedAddressRLPRoundTrip(t *testing.T) {
+	priv, nodeId := generateAddressKey(t)
+	addr := Address{
+		RaftId:   2,
+		NodeId:   nodeId,
+		Ip:       net.IPv4(10, 0, 0, 3),
+		P2pPort:  enr.TCP(30303),
+		RaftPort: enr.RAFTPORT(50001),
+	}
+	signed := NewSignedAddress(addr, 42)
+	if err := signed.Sign(priv); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+
+	enc, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	var out SignedAddress
+	if err := rlp.DecodeBytes(enc, &out); err != nil {
+		t.Fatalf("decode failed: %v", err)
+	}
+	if out.Seq() != signed.Seq() {
+		t.Fatalf("seq mismatch: got %d, want %d", out.Seq(), signed.Seq())
+	}
+	if err := out.Verify(); err != nil {
+		t.Fatalf("decoded record failed verification: %v", err)
+	}
+}
+
+func TestMergeSignedAddressKeepsHigherSeq(t *testing.T) {
+	_, nodeId := generateAddressKey(t)
+	addr := Address{NodeId: nodeId, Ip: net.IPv4(10, 0, 0, 4)}
+
+	older := NewSignedAddress(addr, 1)
+	newer := NewSignedAddress(addr, 2)
+
+	if got := MergeSignedAddress(older, newer); got != newer {
+		t.Fatal("expected merge to keep the higher-seq record")
+	}
+	if got := MergeSignedAddress(newer, older); got != newer {
+		t.Fatal("expected merge to keep the higher-seq record regardless of argument order")
+	}
+	if got := MergeSignedAddress(nil, newer); got != newer {
+		t.Fatal("expected merge to treat nil existing record as losing")
+	}
+	if got := MergeSignedAddress(newer, nil); got != newer {
+		t.Fatal("expected merge to treat nil incoming record as losing")
+	}
+}
+
+func TestBytesToAddressAcceptsValidSignatureWhenEnabled(t *testing.T) {
+	old := atomic.SwapUint32(&raftSignedPeersEnabled, 1)
+	t.Cleanup(func() { atomic.StoreUint32(&raftSignedPeersEnabled, old) })
+
+	priv, nodeId := generateAddressKey(t)
+	addr := Address{
+		RaftId:   3,
+		NodeId:   nodeId,
+		Ip:       net.IPv4(10, 0, 0, 5),
+		P2pPort:  enr.TCP(30303),
+		RaftPort: enr.RAFTPORT(50002),
+	}
+	signed := NewSignedAddress(addr, 1)
+	if err := signed.Sign(priv); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	enc, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	out, err := bytesToAddress(enc)
+	if err != nil {
+		t.Fatalf("bytesToAddress failed: %v", err)
+	}
+	if out.RaftId != addr.RaftId || out.NodeId != addr.NodeId {
+		t.Fatalf("round trip mismatch: got %+v, want %+v", out, addr)
+	}
+}
+
+func TestBytesToAddressRejectsForgedSignatureWithoutCrashing(t *testing.T) {
+	old := atomic.SwapUint32(&raftSignedPeersEnabled, 1)
+	t.Cleanup(func() { atomic.StoreUint32(&raftSignedPeersEnabled, old) })
+
+	priv, nodeId := generateAddressKey(t)
+	addr := Address{
+		RaftId:   4,
+		NodeId:   nodeId,
+		Ip:       net.IPv4(10, 0, 0, 6),
+		P2pPort:  enr.TCP(30303),
+		RaftPort: enr.RAFTPORT(50003),
+	}
+	signed := NewSignedAddress(addr, 1)
+	if err := signed.Sign(priv); err != nil {
+		t.Fatalf("Sign failed: %v", err)
+	}
+	// Forge the NodeId after signing, same as a would-be attacker tampering
+	// with a record in flight.
+	signed.Address.NodeId[0] ^= 0xff
+
+	enc, err := rlp.EncodeToBytes(signed)
+	if err != nil {
+		t.Fatalf("encode failed: %v", err)
+	}
+
+	if _, err := bytesToAddress(enc); err == nil {
+		t.Fatal("expected bytesToAddress to reject a forged signed address")
+	}
+}
+
+func TestBytesToAddressAcceptsLegacyUnsignedDuringRollout(t *testing.T) {
+	old := atomic.SwapUint32(&raftSignedPeersEnabled, 1)
+	t.Cleanup(func() { atomic.StoreUint32(&raftSignedPeersEnabled, old) })
+
+	addr := sampleAddress()
+	enc := addr.toBytes()
+
+	out, err := bytesToAddress(enc)
+	if err != nil {
+		t.Fatalf("expected a legacy unsigned record to still be accepted during rollout: %v", err)
+	}
+	if out.RaftId != addr.RaftId || out.NodeId != addr.NodeId {
+		t.Fatalf("round trip mismatch: got %+v, want %+v", out, addr)
+	}
+}
+
+func TestBytesToAddressRejectsUnsignedInStrictMode(t *testing.T) {
+	oldEnabled := atomic.SwapUint32(&raftSignedPeersEnabled, 1)
+	oldStrict := atomic.SwapUint32(&raftSignedPeersStrict, 1)
+	t.Cleanup(func() {
+		atomic.StoreUint32(&raftSignedPeersEnabled, oldEnabled)
+		atomic.StoreUint32(&raftSignedPeersStrict, oldStrict)
+	})
+
+	addr := sampleAddress()
+	enc := addr.toBytes()
+
+	if _, err := bytesToAddress(enc); err == nil {
+		t.Fatal("expected strict mode to reject a legacy unsigned record")
+	}
+}